@@ -0,0 +1,140 @@
+// Package asyncrequest implements a middleware.Generator that decouples
+// how long a client waits for a response from how long the backend
+// handler actually takes to run. A request that finishes within
+// request_timeout is returned normally; one that doesn't is handed a
+// 202 Accepted pointing at a polling URL while the handler keeps
+// running in the background, bounded by work_timeout.
+package asyncrequest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nwcode4hire/caddy/middleware"
+)
+
+// Result is what a background handler run produces, buffered in a
+// Store until the client polls for it or it is evicted.
+type Result struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Store buffers Results by request ID so the polling handler can
+// retrieve them after the client's original connection has moved on.
+// The default implementation is an in-memory LRU; a Redis-backed (or
+// otherwise persistent) Store can be plugged in instead.
+type Store interface {
+	// Running records that id is running; Put later stores its Result
+	// once the handler finishes. Calling Running again for an id the
+	// Store already holds leaves that entry's state unchanged.
+	Running(id string)
+	Put(id string, result Result)
+
+	// Get returns the current state of id: found is false if id is
+	// unknown (never seen or evicted); running is true if id was
+	// marked Running but has no Result yet.
+	Get(id string) (result Result, running bool, found bool)
+
+	// Evicted reports whether id was once tracked but has since aged
+	// out of the Store, so the polling handler can tell that case
+	// apart from an id that was never issued.
+	Evicted(id string) bool
+}
+
+// Config holds the parsed configuration for an asyncrequest
+// Middleware instance, as produced by NewGenerator from a Caddyfile block.
+type Config struct {
+	RequestTimeout time.Duration
+	WorkTimeout    time.Duration
+
+	// Store buffers results for the polling handler. If nil, NewStore
+	// is used to create an in-memory LRU.
+	Store Store
+
+	// PathPrefix is the base path the polling handler is mounted at;
+	// Location headers are written as PathPrefix + "/" + id.
+	PathPrefix string
+}
+
+// Middleware returns a middleware.Middleware implementing cfg's
+// request/work timeout split. Any request path under cfg.PathPrefix
+// is served directly as the polling endpoint instead of being passed
+// to next, so the returned Middleware is the only thing a caller
+// needs to wire in. base is the context background work is derived
+// from; the caller should cancel it on server Shutdown so no
+// background handler outlives the server.
+func Middleware(base context.Context, cfg Config) middleware.Middleware {
+	store := cfg.Store
+	if store == nil {
+		store = NewStore(1024)
+	}
+	pollPrefix := cfg.PathPrefix + "/"
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, pollPrefix) {
+				servePoll(w, r, store, pollPrefix)
+				return
+			}
+
+			id := newRequestID()
+			store.Running(id)
+
+			rec := newRecorder()
+			done := make(chan struct{})
+
+			workCtx, cancel := context.WithTimeout(base, cfg.WorkTimeout)
+			workReq := r.WithContext(workCtx)
+
+			go func() {
+				defer cancel()
+				defer close(done)
+				next(rec, workReq)
+			}()
+
+			select {
+			case <-done:
+				store.Put(id, rec.result())
+				rec.writeTo(w)
+
+			case <-time.After(cfg.RequestTimeout):
+				w.Header().Set("Location", fmt.Sprintf("%s/%s", cfg.PathPrefix, id))
+				w.WriteHeader(http.StatusAccepted)
+
+				go func() {
+					<-done
+					store.Put(id, rec.result())
+				}()
+			}
+		}
+	}
+}
+
+// servePoll answers a request for cfg.PathPrefix + "/" + id with the
+// buffered Result, 202 while still running, 404 if id was never seen,
+// or 410 if id was seen but has since been evicted from store.
+func servePoll(w http.ResponseWriter, r *http.Request, store Store, pollPrefix string) {
+	id := strings.TrimPrefix(r.URL.Path, pollPrefix)
+	result, running, found := store.Get(id)
+	switch {
+	case found && running:
+		w.WriteHeader(http.StatusAccepted)
+	case found:
+		for k, vs := range result.Header {
+			for _, v := range vs {
+				w.Header().Add(k, v)
+			}
+		}
+		w.WriteHeader(result.StatusCode)
+		w.Write(result.Body)
+	case store.Evicted(id):
+		http.Error(w, "Gone", http.StatusGone)
+	default:
+		http.Error(w, "Not Found", http.StatusNotFound)
+	}
+}
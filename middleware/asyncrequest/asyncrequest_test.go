@@ -0,0 +1,109 @@
+package asyncrequest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareReturnsFastResultDirectly(t *testing.T) {
+	cfg := Config{RequestTimeout: 50 * time.Millisecond, WorkTimeout: time.Second, PathPrefix: "/async"}
+	mw := Middleware(context.Background(), cfg)
+
+	handler := mw(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("done"))
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/widgets", nil))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if rec.Body.String() != "done" {
+		t.Fatalf("got body %q, want %q", rec.Body.String(), "done")
+	}
+}
+
+func TestMiddlewareDefersSlowWorkAndServesPoll(t *testing.T) {
+	cfg := Config{RequestTimeout: 10 * time.Millisecond, WorkTimeout: time.Second, PathPrefix: "/async"}
+	release := make(chan struct{})
+	mw := Middleware(context.Background(), cfg)
+
+	handler := mw(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("late"))
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/widgets", nil))
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	location := rec.Header().Get("Location")
+	if !strings.HasPrefix(location, "/async/") {
+		t.Fatalf("got Location %q, want a /async/<id> path", location)
+	}
+
+	pollReq := httptest.NewRequest(http.MethodGet, location, nil)
+	pollRec := httptest.NewRecorder()
+	handler(pollRec, pollReq)
+	if pollRec.Code != http.StatusAccepted {
+		t.Fatalf("got poll status %d while still running, want %d", pollRec.Code, http.StatusAccepted)
+	}
+
+	close(release)
+	deadline := time.After(time.Second)
+	for {
+		pollRec = httptest.NewRecorder()
+		handler(pollRec, httptest.NewRequest(http.MethodGet, location, nil))
+		if pollRec.Code != http.StatusAccepted {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for the background handler to finish")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	if pollRec.Code != http.StatusOK || pollRec.Body.String() != "late" {
+		t.Fatalf("got status %d body %q, want 200 %q", pollRec.Code, pollRec.Body.String(), "late")
+	}
+}
+
+func TestMiddlewarePollUnknownIDReturns404(t *testing.T) {
+	cfg := Config{RequestTimeout: time.Second, WorkTimeout: time.Second, PathPrefix: "/async"}
+	mw := Middleware(context.Background(), cfg)
+	handler := mw(func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/async/never-seen", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestMiddlewarePollEvictedIDReturns410(t *testing.T) {
+	store := NewStore(1)
+	cfg := Config{RequestTimeout: time.Second, WorkTimeout: time.Second, PathPrefix: "/async", Store: store}
+	mw := Middleware(context.Background(), cfg)
+	handler := mw(func(w http.ResponseWriter, r *http.Request) {})
+
+	store.Put("a", Result{StatusCode: 200})
+	store.Put("b", Result{StatusCode: 200}) // evicts a
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/async/a", nil))
+
+	if rec.Code != http.StatusGone {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusGone)
+	}
+}
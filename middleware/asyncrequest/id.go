@@ -0,0 +1,16 @@
+package asyncrequest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// newRequestID returns a random identifier suitable for use in the
+// Location header and poll URL. Collisions are astronomically
+// unlikely at 16 random bytes, so callers don't need to check Store
+// for an existing entry under the same id.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,44 @@
+package asyncrequest
+
+import "net/http"
+
+// recorder is a minimal http.ResponseWriter that buffers a handler's
+// output instead of writing it to a live connection, so it can be
+// replayed either to the original client (fast path) or stored for a
+// later poll (slow path).
+type recorder struct {
+	header     http.Header
+	body       []byte
+	statusCode int
+}
+
+func newRecorder() *recorder {
+	return &recorder{header: make(http.Header), statusCode: http.StatusOK}
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body = append(r.body, b...)
+	return len(b), nil
+}
+
+func (r *recorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+}
+
+func (r *recorder) result() Result {
+	return Result{StatusCode: r.statusCode, Header: r.header, Body: r.body}
+}
+
+func (r *recorder) writeTo(w http.ResponseWriter) {
+	for k, vs := range r.header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(r.statusCode)
+	w.Write(r.body)
+}
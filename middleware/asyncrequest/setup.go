@@ -0,0 +1,82 @@
+package asyncrequest
+
+import (
+	"context"
+	"time"
+
+	"github.com/nwcode4hire/caddy/middleware"
+)
+
+// NewGenerator returns a middleware.Generator for a directive whose
+// block configures the request/work timeout split:
+//
+//	async_request {
+//		request_timeout 5s
+//		work_timeout    5m
+//		path_prefix     /async
+//	}
+//
+// The returned Middleware serves /async/<id> itself, so no separate
+// route needs to be wired up for it. Controller.Shutdown cancels all
+// in-flight background work so no background handler outlives the
+// server.
+func NewGenerator() middleware.Generator {
+	return func(c middleware.Controller) (middleware.Middleware, error) {
+		cfg := Config{PathPrefix: "/async"}
+
+		for c.Next() {
+			for c.NextBlock() {
+				switch c.Val() {
+				case "request_timeout":
+					d, err := parseDurationArg(c)
+					if err != nil {
+						return nil, err
+					}
+					cfg.RequestTimeout = d
+
+				case "work_timeout":
+					d, err := parseDurationArg(c)
+					if err != nil {
+						return nil, err
+					}
+					cfg.WorkTimeout = d
+
+				case "path_prefix":
+					if !c.NextArg() {
+						return nil, c.ArgErr()
+					}
+					cfg.PathPrefix = c.Val()
+
+				default:
+					return nil, c.ArgErr()
+				}
+			}
+		}
+
+		if cfg.RequestTimeout <= 0 {
+			return nil, c.Err("async_request: request_timeout must be set and positive")
+		}
+		if cfg.WorkTimeout <= 0 {
+			return nil, c.Err("async_request: work_timeout must be set and positive")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		c.Shutdown(func() error {
+			cancel()
+			return nil
+		})
+
+		return Middleware(ctx, cfg), nil
+	}
+}
+
+func parseDurationArg(c middleware.Controller) (time.Duration, error) {
+	if !c.NextArg() {
+		return 0, c.ArgErr()
+	}
+	d, err := time.ParseDuration(c.Val())
+	if err != nil {
+		return 0, c.Err("invalid duration: " + err.Error())
+	}
+	return d, nil
+}
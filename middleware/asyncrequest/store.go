@@ -0,0 +1,130 @@
+package asyncrequest
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruStore is the default, in-memory Store. It evicts the
+// least-recently-touched entry once more than capacity entries are
+// held, so a server that never gets polled for old results doesn't
+// leak memory indefinitely. Evicted IDs are remembered for a while
+// too (bounded the same way) so Evicted can tell an aged-out ID apart
+// from one that was never seen.
+type lruStore struct {
+	mu       sync.Mutex
+	capacity int
+
+	order   *list.List
+	entries map[string]*list.Element
+
+	evictedOrder   *list.List
+	evictedEntries map[string]*list.Element
+}
+
+type entry struct {
+	id      string
+	result  Result
+	running bool
+}
+
+// NewStore returns the default in-memory Store, keeping at most
+// capacity entries before evicting the least-recently-touched one.
+func NewStore(capacity int) Store {
+	return &lruStore{
+		capacity:       capacity,
+		order:          list.New(),
+		entries:        make(map[string]*list.Element),
+		evictedOrder:   list.New(),
+		evictedEntries: make(map[string]*list.Element),
+	}
+}
+
+func (s *lruStore) Running(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[id]; ok {
+		s.order.MoveToFront(el)
+		return
+	}
+
+	s.unmarkEvicted(id)
+	el := s.order.PushFront(&entry{id: id, running: true})
+	s.entries[id] = el
+	s.evictIfNeeded()
+}
+
+func (s *lruStore) Put(id string, result Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[id]; ok {
+		e := el.Value.(*entry)
+		e.result = result
+		e.running = false
+		s.order.MoveToFront(el)
+		return
+	}
+
+	s.unmarkEvicted(id)
+	el := s.order.PushFront(&entry{id: id, result: result})
+	s.entries[id] = el
+	s.evictIfNeeded()
+}
+
+func (s *lruStore) Get(id string) (Result, bool, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[id]
+	if !ok {
+		return Result{}, false, false
+	}
+	e := el.Value.(*entry)
+	return e.result, e.running, true
+}
+
+func (s *lruStore) Evicted(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.evictedEntries[id]
+	return ok
+}
+
+func (s *lruStore) evictIfNeeded() {
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		if oldest == nil {
+			return
+		}
+		id := oldest.Value.(*entry).id
+		s.order.Remove(oldest)
+		delete(s.entries, id)
+		s.markEvicted(id)
+	}
+}
+
+func (s *lruStore) markEvicted(id string) {
+	el := s.evictedOrder.PushFront(id)
+	s.evictedEntries[id] = el
+
+	for s.evictedOrder.Len() > s.capacity {
+		oldest := s.evictedOrder.Back()
+		if oldest == nil {
+			return
+		}
+		s.evictedOrder.Remove(oldest)
+		delete(s.evictedEntries, oldest.Value.(string))
+	}
+}
+
+func (s *lruStore) unmarkEvicted(id string) {
+	el, ok := s.evictedEntries[id]
+	if !ok {
+		return
+	}
+	s.evictedOrder.Remove(el)
+	delete(s.evictedEntries, id)
+}
@@ -0,0 +1,63 @@
+package asyncrequest
+
+import "testing"
+
+func TestStoreGetUnknownID(t *testing.T) {
+	s := NewStore(2)
+	_, _, found := s.Get("missing")
+	if found {
+		t.Fatal("expected found=false for an id that was never seen")
+	}
+	if s.Evicted("missing") {
+		t.Fatal("expected Evicted=false for an id that was never seen")
+	}
+}
+
+func TestStoreRunningThenPut(t *testing.T) {
+	s := NewStore(2)
+	s.Running("a")
+
+	_, running, found := s.Get("a")
+	if !found || !running {
+		t.Fatalf("got running=%v found=%v, want true, true", running, found)
+	}
+
+	s.Put("a", Result{StatusCode: 200, Body: []byte("ok")})
+	result, running, found := s.Get("a")
+	if !found || running {
+		t.Fatalf("got running=%v found=%v, want false, true", running, found)
+	}
+	if string(result.Body) != "ok" {
+		t.Fatalf("got body %q, want %q", result.Body, "ok")
+	}
+}
+
+func TestStoreEvictsOldestBeyondCapacity(t *testing.T) {
+	s := NewStore(1)
+	s.Put("a", Result{StatusCode: 200})
+	s.Put("b", Result{StatusCode: 200})
+
+	if _, _, found := s.Get("a"); found {
+		t.Fatal("expected a to have been evicted")
+	}
+	if !s.Evicted("a") {
+		t.Fatal("expected Evicted(a)=true after it aged out, to distinguish it from an unknown id")
+	}
+	if _, _, found := s.Get("b"); !found {
+		t.Fatal("expected b (most recently touched) to still be present")
+	}
+}
+
+func TestStoreForgetsEvictionHistoryBeyondItsOwnCapacity(t *testing.T) {
+	s := NewStore(1)
+	s.Put("a", Result{StatusCode: 200})
+	s.Put("b", Result{StatusCode: 200}) // evicts a
+	s.Put("c", Result{StatusCode: 200}) // evicts b, and a's eviction record ages out too
+
+	if s.Evicted("a") {
+		t.Fatal("expected a's eviction record to have aged out once the eviction history itself overflowed")
+	}
+	if !s.Evicted("b") {
+		t.Fatal("expected b to still be remembered as evicted")
+	}
+}
@@ -0,0 +1,161 @@
+// Package codec implements content negotiation for middleware request
+// and response bodies. It registers encoders and decoders by MIME
+// type and provides a Middleware that picks a decoder for the request
+// body from Content-Type and an encoder for the response body from
+// Accept, stashing both on the request context so downstream handlers
+// never repeat the negotiation logic themselves.
+package codec
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+type (
+	// Decoder reads v out of the body of r.
+	Decoder interface {
+		Decode(r *http.Request, v interface{}) error
+	}
+
+	// Encoder writes v to w, setting any headers (such as Content-Type)
+	// that the wire format requires.
+	Encoder interface {
+		Encode(w http.ResponseWriter, v interface{}) error
+	}
+)
+
+// contextKey is unexported so codec.RequestCodec and codec.ResponseCodec
+// are the only way to read values stored by Middleware.
+type contextKey int
+
+const (
+	decoderKey contextKey = iota
+	encoderKey
+)
+
+var registry = map[string]struct {
+	Decoder
+	Encoder
+}{}
+
+// Register associates mimeType with a Decoder and Encoder pair so that
+// it can be selected during negotiation. Register is typically called
+// from an init function in a package implementing a wire format.
+func Register(mimeType string, dec Decoder, enc Encoder) {
+	registry[mimeType] = struct {
+		Decoder
+		Encoder
+	}{dec, enc}
+}
+
+// RequestCodec returns the Decoder that was negotiated for r's body,
+// or nil if Middleware did not run or no decoder was needed.
+func RequestCodec(r *http.Request) Decoder {
+	dec, _ := r.Context().Value(decoderKey).(Decoder)
+	return dec
+}
+
+// ResponseCodec returns the Encoder that was negotiated for the
+// response to r, or nil if Middleware did not run or no encoder was needed.
+func ResponseCodec(r *http.Request) Encoder {
+	enc, _ := r.Context().Value(encoderKey).(Encoder)
+	return enc
+}
+
+// Config controls which codecs Middleware will negotiate and what it
+// falls back to when Accept is absent or "*/*".
+type Config struct {
+	// Enabled lists the MIME types eligible for negotiation. A MIME
+	// type with no Decoder/Encoder registered under it is ignored.
+	Enabled []string
+
+	// Default is used to pick a response encoder when Accept is
+	// missing or "*/*". It must be one of Enabled.
+	Default string
+}
+
+// Middleware returns a plain http.HandlerFunc wrapper, with the same
+// shape as middleware.Middleware, that negotiates a Decoder and Encoder
+// for each request according to cfg and makes them available via
+// RequestCodec and ResponseCodec.
+func Middleware(cfg Config) func(http.HandlerFunc) http.HandlerFunc {
+	enabled := make(map[string]bool, len(cfg.Enabled))
+	for _, m := range cfg.Enabled {
+		enabled[m] = true
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			if ct := r.Header.Get("Content-Type"); ct != "" {
+				mt, _, err := mime.ParseMediaType(ct)
+				c, registered := registry[mt]
+				if err != nil || !enabled[mt] || !registered {
+					http.Error(w, "Unsupported Media Type", http.StatusUnsupportedMediaType)
+					return
+				}
+				ctx = context.WithValue(ctx, decoderKey, c.Decoder)
+			}
+
+			accept := r.Header.Get("Accept")
+			mt := negotiate(accept, cfg.Default, enabled)
+			if mt == "" {
+				http.Error(w, "Not Acceptable", http.StatusNotAcceptable)
+				return
+			}
+			if c, ok := registry[mt]; ok {
+				ctx = context.WithValue(ctx, encoderKey, c.Encoder)
+			}
+
+			next(w, r.WithContext(ctx))
+		}
+	}
+}
+
+// negotiate picks the first MIME type in accept (a raw Accept header
+// value) that is enabled and registered. An empty or "*/*" accept
+// falls back to def. It returns "" if nothing acceptable is available.
+func negotiate(accept, def string, enabled map[string]bool) string {
+	if accept == "" || accept == "*/*" {
+		if enabled[def] {
+			if _, ok := registry[def]; ok {
+				return def
+			}
+		}
+		return ""
+	}
+
+	for _, part := range splitAccept(accept) {
+		if part == "*/*" {
+			if enabled[def] {
+				if _, ok := registry[def]; ok {
+					return def
+				}
+			}
+			continue
+		}
+		if enabled[part] {
+			if _, ok := registry[part]; ok {
+				return part
+			}
+		}
+	}
+	return ""
+}
+
+// splitAccept breaks a comma-separated Accept header into bare MIME
+// types, discarding any ";q=..." parameters.
+func splitAccept(accept string) []string {
+	var types []string
+	for _, raw := range strings.Split(accept, ",") {
+		mt, _, err := mime.ParseMediaType(strings.TrimSpace(raw))
+		if err != nil {
+			continue
+		}
+		types = append(types, mt)
+	}
+	return types
+}
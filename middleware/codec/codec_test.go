@@ -0,0 +1,73 @@
+package codec
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeCodec struct{}
+
+func (fakeCodec) Decode(r *http.Request, v interface{}) error       { return nil }
+func (fakeCodec) Encode(w http.ResponseWriter, v interface{}) error { return nil }
+
+func TestMiddlewareRejectsUnsupportedContentType(t *testing.T) {
+	Register("application/test-unsupported+json", fakeCodec{}, fakeCodec{})
+	cfg := Config{Enabled: []string{"application/test-unsupported+json"}, Default: "application/test-unsupported+json"}
+
+	mw := Middleware(cfg)
+	handler := mw(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next handler should not run for an unsupported Content-Type")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Content-Type", "application/not-negotiated")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusUnsupportedMediaType)
+	}
+}
+
+func TestMiddlewareRejectsUnparseableContentType(t *testing.T) {
+	Register("application/test-unparseable+json", fakeCodec{}, fakeCodec{})
+	cfg := Config{Enabled: []string{"application/test-unparseable+json"}, Default: "application/test-unparseable+json"}
+
+	mw := Middleware(cfg)
+	handler := mw(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next handler should not run for a malformed Content-Type")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Content-Type", ";;;not-a-mime-type")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusUnsupportedMediaType)
+	}
+}
+
+func TestMiddlewareNegotiatesEnabledContentType(t *testing.T) {
+	Register("application/test-negotiated+json", fakeCodec{}, fakeCodec{})
+	cfg := Config{Enabled: []string{"application/test-negotiated+json"}, Default: "application/test-negotiated+json"}
+
+	mw := Middleware(cfg)
+	var gotDecoder Decoder
+	handler := mw(func(w http.ResponseWriter, r *http.Request) {
+		gotDecoder = RequestCodec(r)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Content-Type", "application/test-negotiated+json")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if gotDecoder == nil {
+		t.Fatal("expected a decoder to be negotiated for an enabled, registered Content-Type")
+	}
+}
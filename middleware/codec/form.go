@@ -0,0 +1,39 @@
+package codec
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+func init() {
+	Register("application/x-www-form-urlencoded", formCodec{}, formCodec{})
+}
+
+// formCodec only supports decoding into *url.Values and encoding from
+// url.Values,
+// since form-urlencoded has no notion of nested or typed fields the
+// way JSON or XML do.
+type formCodec struct{}
+
+func (formCodec) Decode(r *http.Request, v interface{}) error {
+	dst, ok := v.(*url.Values)
+	if !ok {
+		return errors.New("codec: form decoder requires *url.Values")
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	*dst = r.PostForm
+	return nil
+}
+
+func (formCodec) Encode(w http.ResponseWriter, v interface{}) error {
+	values, ok := v.(url.Values)
+	if !ok {
+		return errors.New("codec: form encoder requires url.Values")
+	}
+	w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err := w.Write([]byte(values.Encode()))
+	return err
+}
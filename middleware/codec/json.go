@@ -0,0 +1,22 @@
+package codec
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func init() {
+	Register("application/json", jsonCodec{}, jsonCodec{})
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Decode(r *http.Request, v interface{}) error {
+	defer r.Body.Close()
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func (jsonCodec) Encode(w http.ResponseWriter, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(v)
+}
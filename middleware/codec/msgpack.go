@@ -0,0 +1,25 @@
+package codec
+
+import (
+	"net/http"
+
+	"github.com/ugorji/go/codec"
+)
+
+var msgpackHandle codec.MsgpackHandle
+
+func init() {
+	Register("application/msgpack", msgpackCodec{}, msgpackCodec{})
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Decode(r *http.Request, v interface{}) error {
+	defer r.Body.Close()
+	return codec.NewDecoder(r.Body, &msgpackHandle).Decode(v)
+}
+
+func (msgpackCodec) Encode(w http.ResponseWriter, v interface{}) error {
+	w.Header().Set("Content-Type", "application/msgpack")
+	return codec.NewEncoder(w, &msgpackHandle).Encode(v)
+}
@@ -0,0 +1,45 @@
+package codec
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func init() {
+	Register("application/x-protobuf", protobufCodec{}, protobufCodec{})
+}
+
+// protobufCodec only supports decoding into and encoding from types
+// implementing proto.Message, since protobuf has no self-describing
+// schema the way JSON or XML do.
+type protobufCodec struct{}
+
+func (protobufCodec) Decode(r *http.Request, v interface{}) error {
+	defer r.Body.Close()
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return errors.New("codec: protobuf decoder requires a proto.Message")
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(body, msg)
+}
+
+func (protobufCodec) Encode(w http.ResponseWriter, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return errors.New("codec: protobuf encoder requires a proto.Message")
+	}
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, err = w.Write(body)
+	return err
+}
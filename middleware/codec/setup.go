@@ -0,0 +1,48 @@
+package codec
+
+import (
+	"fmt"
+
+	"github.com/nwcode4hire/caddy/middleware"
+)
+
+// NewGenerator returns a middleware.Generator for a directive whose
+// block lists the MIME types to negotiate, one per line, with an
+// optional `default <mime-type>` line naming the codec to use when
+// Accept is absent or "*/*":
+//
+//	codec {
+//		application/json
+//		application/xml
+//		default application/json
+//	}
+func NewGenerator() middleware.Generator {
+	return func(c middleware.Controller) (middleware.Middleware, error) {
+		var cfg Config
+
+		for c.Next() {
+			for c.NextBlock() {
+				switch c.Val() {
+				case "default":
+					if !c.NextArg() {
+						return nil, c.ArgErr()
+					}
+					cfg.Default = c.Val()
+				default:
+					cfg.Enabled = append(cfg.Enabled, c.Val())
+				}
+			}
+		}
+
+		if cfg.Default == "" && len(cfg.Enabled) > 0 {
+			cfg.Default = cfg.Enabled[0]
+		}
+		for _, mt := range cfg.Enabled {
+			if _, ok := registry[mt]; !ok {
+				return nil, fmt.Errorf("codec: no codec registered for %q", mt)
+			}
+		}
+
+		return Middleware(cfg), nil
+	}
+}
@@ -0,0 +1,22 @@
+package codec
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+func init() {
+	Register("application/xml", xmlCodec{}, xmlCodec{})
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) Decode(r *http.Request, v interface{}) error {
+	defer r.Body.Close()
+	return xml.NewDecoder(r.Body).Decode(v)
+}
+
+func (xmlCodec) Encode(w http.ResponseWriter, v interface{}) error {
+	w.Header().Set("Content-Type", "application/xml")
+	return xml.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,232 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// token and testDispenser are a minimal, self-contained stand-in for
+// the real Caddyfile dispenser that backs Controller in production.
+// They exist only so this package can exercise and pin down the
+// nested-block contract described on NextBlock/NestingLevel without
+// depending on the server's own tokenizer.
+type token struct {
+	text string
+	line int
+}
+
+// tokenize splits input into tokens, treating "{" and "}" as their
+// own tokens regardless of surrounding whitespace.
+func tokenize(input string) []token {
+	var tokens []token
+	for i, line := range strings.Split(input, "\n") {
+		lineNum := i + 1
+		spaced := strings.NewReplacer("{", " { ", "}", " } ").Replace(line)
+		for _, f := range strings.Fields(spaced) {
+			tokens = append(tokens, token{text: f, line: lineNum})
+		}
+	}
+	return tokens
+}
+
+type testDispenser struct {
+	tokens []token
+	cursor int
+	depth  int
+}
+
+func newTestDispenser(input string) *testDispenser {
+	return &testDispenser{tokens: tokenize(input), cursor: -1}
+}
+
+func (d *testDispenser) Next() bool {
+	if d.cursor+1 >= len(d.tokens) {
+		return false
+	}
+	d.cursor++
+	return true
+}
+
+func (d *testDispenser) sameLineAsCurrent(i int) bool {
+	return d.cursor >= 0 && i < len(d.tokens) && d.tokens[i].line == d.tokens[d.cursor].line
+}
+
+func (d *testDispenser) NextArg() bool {
+	if !d.sameLineAsCurrent(d.cursor + 1) {
+		return false
+	}
+	d.cursor++
+	return true
+}
+
+func (d *testDispenser) NextLine() bool {
+	if d.cursor+1 >= len(d.tokens) || d.sameLineAsCurrent(d.cursor+1) {
+		return false
+	}
+	d.cursor++
+	return true
+}
+
+// NextBlock maintains depth the way the request describes: a '{'
+// encountered while already inside a block increments depth and is
+// surfaced as a token; a matching '}' decrements it, and NextBlock
+// only returns false once depth returns to zero.
+func (d *testDispenser) NextBlock() bool {
+	if d.depth > 0 {
+		if !d.Next() {
+			return false
+		}
+	} else {
+		if !d.NextArg() && !d.NextLine() {
+			return false
+		}
+		if d.Val() != "{" {
+			return false
+		}
+	}
+
+	switch d.Val() {
+	case "{":
+		d.depth++
+		return true
+	case "}":
+		d.depth--
+		return d.depth != 0
+	default:
+		return true
+	}
+}
+
+func (d *testDispenser) NestingLevel() int {
+	return d.depth
+}
+
+func (d *testDispenser) Val() string {
+	if d.cursor < 0 || d.cursor >= len(d.tokens) {
+		return ""
+	}
+	return d.tokens[d.cursor].text
+}
+
+func (d *testDispenser) Args(targets ...*string) bool {
+	for _, t := range targets {
+		if !d.NextArg() {
+			return false
+		}
+		*t = d.Val()
+	}
+	return true
+}
+
+func (d *testDispenser) RemainingArgs() []string {
+	var args []string
+	for d.sameLineAsCurrent(d.cursor+1) && d.tokens[d.cursor+1].text != "{" {
+		d.cursor++
+		args = append(args, d.Val())
+	}
+	return args
+}
+
+func (d *testDispenser) ArgErr() error {
+	return d.Err("expected an argument, found none")
+}
+
+func (d *testDispenser) Err(msg string) error {
+	line := 0
+	switch {
+	case d.cursor >= 0 && d.cursor < len(d.tokens):
+		line = d.tokens[d.cursor].line
+	case len(d.tokens) > 0:
+		line = d.tokens[len(d.tokens)-1].line
+	}
+	return fmt.Errorf("line %d: %s", line, msg)
+}
+
+func (d *testDispenser) Startup(func() error)  {}
+func (d *testDispenser) Shutdown(func() error) {}
+func (d *testDispenser) Root() string          { return "" }
+func (d *testDispenser) Host() string          { return "" }
+func (d *testDispenser) Port() string          { return "" }
+func (d *testDispenser) Context() Path         { return "" }
+
+func (d *testDispenser) OnEvent(name EventName, fn func(EventInfo) error) {
+	OnEvent(name, fn)
+}
+
+func (d *testDispenser) OnEventPriority(name EventName, priority int, fn func(EventInfo) error) {
+	OnEventPriority(name, priority, fn)
+}
+
+var _ Controller = (*testDispenser)(nil)
+
+// drainBlock exercises the contract a real Generator relies on: drain
+// NextBlock until it returns false, then check NestingLevel to notice
+// an unclosed block and report it through Err, the same sequence a
+// Generator would use to surface unexpected EOF partway through a
+// block.
+func drainBlock(c Controller) error {
+	for c.NextBlock() {
+	}
+	if c.NestingLevel() != 0 {
+		return c.Err("unexpected EOF, unclosed block")
+	}
+	return nil
+}
+
+func TestNextBlockTracksMultiLevelNesting(t *testing.T) {
+	d := newTestDispenser("matcher {\n  header {\n    name X\n  }\n}\n")
+	d.Next() // "matcher"
+
+	var vals []string
+	var depths []int
+	for d.NextBlock() {
+		vals = append(vals, d.Val())
+		depths = append(depths, d.NestingLevel())
+	}
+
+	wantVals := []string{"{", "header", "{", "name", "X", "}"}
+	wantDepths := []int{1, 1, 2, 2, 2, 1}
+	if len(vals) != len(wantVals) {
+		t.Fatalf("got tokens %v, want %v", vals, wantVals)
+	}
+	for i := range wantVals {
+		if vals[i] != wantVals[i] || depths[i] != wantDepths[i] {
+			t.Fatalf("token %d: got (%q, depth %d), want (%q, depth %d)", i, vals[i], depths[i], wantVals[i], wantDepths[i])
+		}
+	}
+	if d.NestingLevel() != 0 {
+		t.Fatalf("got NestingLevel()=%d after the outer block closed, want 0", d.NestingLevel())
+	}
+}
+
+func TestNextBlockMixesWithNextArgOnOpeningLine(t *testing.T) {
+	d := newTestDispenser("matcher arg1 {\n  foo\n}\n")
+	d.Next() // "matcher"
+	if !d.NextArg() || d.Val() != "arg1" {
+		t.Fatalf("expected NextArg to load %q on the opening line", "arg1")
+	}
+
+	var vals []string
+	for d.NextBlock() {
+		vals = append(vals, d.Val())
+	}
+
+	want := []string{"{", "foo"}
+	if len(vals) != len(want) || vals[0] != want[0] || vals[1] != want[1] {
+		t.Fatalf("got tokens %v, want %v", vals, want)
+	}
+	if d.NestingLevel() != 0 {
+		t.Fatalf("got NestingLevel()=%d after the block closed, want 0", d.NestingLevel())
+	}
+}
+
+func TestNextBlockUnbalancedBracesErrAtExactLine(t *testing.T) {
+	d := newTestDispenser("matcher {\n  header {\n    name X\n")
+	d.Next() // "matcher"
+
+	err := drainBlock(d)
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Fatalf("got error %v, want it to report line 3 (the last token read)", err)
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"sort"
+	"sync"
+)
+
+// EventName identifies a point in the server lifecycle at which
+// subscribed handlers are notified via EmitEvent.
+type EventName string
+
+const (
+	// StartupEvent fires after all Generators have been built but
+	// before the server begins accepting connections.
+	StartupEvent EventName = "startup"
+
+	// ShutdownEvent fires when the server begins its shutdown sequence.
+	ShutdownEvent EventName = "shutdown"
+
+	// ConfigReloadEvent fires after a configuration reload has completed.
+	ConfigReloadEvent EventName = "config_reload"
+
+	// CertRenewedEvent fires after a TLS certificate has been renewed.
+	CertRenewedEvent EventName = "cert_renewed"
+
+	// RequestErrorEvent fires when a request fails in a way that is
+	// useful to report on, such as a recovered panic.
+	RequestErrorEvent EventName = "request_error"
+)
+
+// EventInfo carries the payload passed to EmitEvent along with a copy
+// of the event name it was emitted under.
+type EventInfo struct {
+	Name    EventName
+	Payload interface{}
+}
+
+// handler pairs a subscribed function with the priority it registered
+// under, so handlers can be sorted without losing registration order
+// among equal priorities.
+type handler struct {
+	fn       func(EventInfo) error
+	priority int
+	seq      int
+}
+
+var (
+	mu          sync.Mutex
+	subscribers = map[EventName][]handler{}
+	seqCounter  int
+)
+
+// OnEvent subscribes fn to be called whenever name is emitted via
+// EmitEvent, at the default priority (0). Handlers run in order of
+// priority (lower runs first); handlers registered with the same
+// priority run in registration order. OnEvent is typically called by
+// a Generator while it is parsing its Controller.
+func OnEvent(name EventName, fn func(EventInfo) error) {
+	OnEventPriority(name, 0, fn)
+}
+
+// OnEventPriority is like OnEvent but lets a subscriber control where
+// it runs relative to other subscribers of the same event. Lower
+// priority values run first.
+func OnEventPriority(name EventName, priority int, fn func(EventInfo) error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	seqCounter++
+	subscribers[name] = append(subscribers[name], handler{fn: fn, priority: priority, seq: seqCounter})
+	sort.SliceStable(subscribers[name], func(i, j int) bool {
+		return subscribers[name][i].priority < subscribers[name][j].priority
+	})
+}
+
+// EmitEvent calls every handler subscribed to name, in priority order,
+// passing payload along. If a handler returns a non-nil error, EmitEvent
+// stops calling further handlers and returns that error. For
+// StartupEvent, a non-nil error returned here is meant to abort server
+// bring-up; callers are responsible for treating it that way.
+//
+// EmitEvent takes a snapshot of the subscriber list under lock before
+// calling any of them, since it is called from request-handling
+// goroutines (via DefaultPanicHandler) concurrently with Generators
+// still subscribing through OnEvent/OnEventPriority.
+func EmitEvent(name EventName, payload interface{}) error {
+	mu.Lock()
+	handlers := append([]handler(nil), subscribers[name]...)
+	mu.Unlock()
+
+	info := EventInfo{Name: name, Payload: payload}
+	for _, h := range handlers {
+		if err := h.fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestEmitEventRunsHandlersInPriorityOrder(t *testing.T) {
+	const name EventName = "test_priority_order"
+	var order []string
+
+	OnEventPriority(name, 10, func(EventInfo) error { order = append(order, "low-priority"); return nil })
+	OnEventPriority(name, 0, func(EventInfo) error { order = append(order, "high-priority-first"); return nil })
+	OnEventPriority(name, 0, func(EventInfo) error { order = append(order, "high-priority-second"); return nil })
+
+	if err := EmitEvent(name, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"high-priority-first", "high-priority-second", "low-priority"}
+	if len(order) != len(want) {
+		t.Fatalf("got %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("got %v, want %v", order, want)
+		}
+	}
+}
+
+func TestEmitEventStopsOnFirstError(t *testing.T) {
+	const name EventName = "test_stop_on_error"
+	boom := errTest("boom")
+	var secondCalled bool
+
+	OnEvent(name, func(EventInfo) error { return boom })
+	OnEvent(name, func(EventInfo) error { secondCalled = true; return nil })
+
+	if err := EmitEvent(name, nil); err != boom {
+		t.Fatalf("got error %v, want %v", err, boom)
+	}
+	if secondCalled {
+		t.Fatal("expected EmitEvent to stop after the first error")
+	}
+}
+
+type errTest string
+
+func (e errTest) Error() string { return string(e) }
+
+// TestOnEventPriorityConcurrentWithEmitEvent exercises subscribing and
+// emitting from multiple goroutines at once, matching how
+// DefaultPanicHandler emits RequestErrorEvent from request-handling
+// goroutines while Generators may still be subscribing. Run with
+// -race to verify the subscriber map is properly guarded.
+func TestOnEventPriorityConcurrentWithEmitEvent(t *testing.T) {
+	const name EventName = "test_concurrent"
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			OnEvent(name, func(EventInfo) error { return nil })
+		}()
+		go func() {
+			defer wg.Done()
+			EmitEvent(name, nil)
+		}()
+	}
+	wg.Wait()
+}
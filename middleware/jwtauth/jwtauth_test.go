@@ -0,0 +1,141 @@
+package jwtauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+const testSecret = "test-secret"
+
+func signHS256(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return signed
+}
+
+func TestVerifyAcceptsValidToken(t *testing.T) {
+	cfg := Config{
+		Algorithm: "HS256",
+		Keys:      staticKey{key: []byte(testSecret)},
+		Audience:  "my-api",
+		Issuer:    "https://issuer.example.com",
+	}
+	raw := signHS256(t, jwt.MapClaims{
+		"aud": "my-api",
+		"iss": "https://issuer.example.com",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	claims, err := verify(raw, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claims["aud"] != "my-api" {
+		t.Fatalf("got aud %v, want my-api", claims["aud"])
+	}
+}
+
+func TestVerifyRejectsExpiredToken(t *testing.T) {
+	cfg := Config{Algorithm: "HS256", Keys: staticKey{key: []byte(testSecret)}}
+	raw := signHS256(t, jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})
+
+	if _, err := verify(raw, cfg); err == nil {
+		t.Fatal("expected an error for an expired token")
+	}
+}
+
+func TestVerifyRejectsWrongAudience(t *testing.T) {
+	cfg := Config{Algorithm: "HS256", Keys: staticKey{key: []byte(testSecret)}, Audience: "my-api"}
+	raw := signHS256(t, jwt.MapClaims{"aud": "someone-else"})
+
+	if _, err := verify(raw, cfg); err == nil {
+		t.Fatal("expected an error for a mismatched audience")
+	}
+}
+
+func TestVerifyRejectsMissingRequiredClaim(t *testing.T) {
+	cfg := Config{Algorithm: "HS256", Keys: staticKey{key: []byte(testSecret)}, RequiredClaims: []string{"sub"}}
+	raw := signHS256(t, jwt.MapClaims{})
+
+	if _, err := verify(raw, cfg); err == nil {
+		t.Fatal("expected an error for a missing required claim")
+	}
+}
+
+func TestVerifyRejectsZeroValueRequiredClaim(t *testing.T) {
+	// A claim that's present but empty/zero ("", 0, false) must not
+	// satisfy RequiredClaims, per its doc: it requires a non-zero value.
+	cfg := Config{Algorithm: "HS256", Keys: staticKey{key: []byte(testSecret)}, RequiredClaims: []string{"sub"}}
+	raw := signHS256(t, jwt.MapClaims{"sub": ""})
+
+	if _, err := verify(raw, cfg); err == nil {
+		t.Fatal("expected an error for a required claim present with a zero value")
+	}
+}
+
+func TestVerifyAcceptsNonZeroRequiredClaim(t *testing.T) {
+	cfg := Config{Algorithm: "HS256", Keys: staticKey{key: []byte(testSecret)}, RequiredClaims: []string{"sub"}}
+	raw := signHS256(t, jwt.MapClaims{"sub": "user-1"})
+
+	if _, err := verify(raw, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestVerifyRejectsAlgorithmMismatch(t *testing.T) {
+	// Configured for RS256 but the token is HS256-signed; verify must
+	// reject it rather than trust the token's own header.
+	cfg := Config{Algorithm: "RS256", Keys: staticKey{key: []byte(testSecret)}}
+	raw := signHS256(t, jwt.MapClaims{})
+
+	if _, err := verify(raw, cfg); err == nil {
+		t.Fatal("expected an error for an algorithm-confusion attempt")
+	}
+}
+
+func TestMiddlewareRejectsMissingToken(t *testing.T) {
+	mw := Middleware(Config{
+		Algorithm: "HS256",
+		Keys:      staticKey{key: []byte(testSecret)},
+		Protect:   []string{"/api"},
+	})
+	handler := mw(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next handler should not run without a token")
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/widgets", nil)
+	handler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestMiddlewarePassesThroughUnprotectedPath(t *testing.T) {
+	mw := Middleware(Config{
+		Algorithm: "HS256",
+		Keys:      staticKey{key: []byte(testSecret)},
+		Protect:   []string{"/api"},
+	})
+	called := false
+	handler := mw(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/public", nil)
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("expected the next handler to run for an unprotected path")
+	}
+}
@@ -0,0 +1,233 @@
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+
+	"github.com/nwcode4hire/caddy/middleware"
+)
+
+// staticKey serves a single, fixed key regardless of algorithm or kid.
+// It backs both inline key material and keys loaded from a file, since
+// neither changes once the Generator has parsed its Controller.
+type staticKey struct {
+	key interface{}
+}
+
+func (s staticKey) Key(alg, kid string) (interface{}, error) {
+	return s.key, nil
+}
+
+// newInlineKey parses raw key material supplied directly in the
+// Caddyfile for the given algorithm family.
+func newInlineKey(alg string, raw []byte) (KeySource, error) {
+	key, err := parseKey(alg, raw)
+	if err != nil {
+		return nil, err
+	}
+	return staticKey{key: key}, nil
+}
+
+// newFileKey reads key material from path on disk for the given
+// algorithm family.
+func newFileKey(alg, path string) (KeySource, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("jwtauth: reading key file: %v", err)
+	}
+	return newInlineKey(alg, raw)
+}
+
+func parseKey(alg string, raw []byte) (interface{}, error) {
+	switch alg {
+	case "HS256":
+		return raw, nil
+	case "RS256":
+		key, err := jwt.ParseRSAPublicKeyFromPEM(raw)
+		if err != nil {
+			return nil, fmt.Errorf("jwtauth: parsing RSA public key: %v", err)
+		}
+		return key, nil
+	case "ES256":
+		key, err := jwt.ParseECPublicKeyFromPEM(raw)
+		if err != nil {
+			return nil, fmt.Errorf("jwtauth: parsing EC public key: %v", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("jwtauth: unsupported algorithm %q", alg)
+	}
+}
+
+// jwksKey fetches and periodically refreshes a JSON Web Key Set from a
+// URL, serving keys looked up by kid. A background goroutine is
+// started via Controller.Startup and stopped via Controller.Shutdown
+// so the refresh loop never outlives the server.
+type jwksKey struct {
+	url      string
+	interval time.Duration
+
+	mu   sync.RWMutex
+	keys map[string]interface{}
+
+	stop chan struct{}
+}
+
+// newJWKSKey constructs a jwksKey and wires its background refresh
+// loop into c's Startup/Shutdown hooks. It fetches nothing itself: the
+// Startup hook performs the first fetch synchronously, returning its
+// error, and starts the refresh loop only once that fetch succeeds.
+func newJWKSKey(c middleware.Controller, url string, interval time.Duration) (*jwksKey, error) {
+	if interval <= 0 {
+		interval = defaultJWKSRefresh
+	}
+	j := &jwksKey{url: url, interval: interval, stop: make(chan struct{})}
+
+	c.Startup(func() error {
+		if err := j.refresh(); err != nil {
+			return err
+		}
+		go j.loop()
+		return nil
+	})
+	c.Shutdown(func() error {
+		close(j.stop)
+		return nil
+	})
+
+	return j, nil
+}
+
+func (j *jwksKey) loop() {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			j.refresh() // errors are left in place; the last good key set keeps serving
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+type jwksDoc struct {
+	Keys []struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	} `json:"keys"`
+}
+
+func (j *jwksKey) refresh() error {
+	resp, err := http.Get(j.url)
+	if err != nil {
+		return fmt.Errorf("jwtauth: fetching JWKS: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var doc jwksDoc
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("jwtauth: decoding JWKS: %v", err)
+	}
+
+	keys := make(map[string]interface{}, len(doc.Keys))
+	for _, k := range doc.Keys {
+		var (
+			pub interface{}
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			pub, err = rsaPublicKeyFromJWK(k.N, k.E)
+		case "EC":
+			pub, err = ecPublicKeyFromJWK(k.Crv, k.X, k.Y)
+		default:
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.mu.Unlock()
+	return nil
+}
+
+// rsaPublicKeyFromJWK reconstructs an RSA public key from the
+// base64url-encoded modulus (n) and exponent (e) fields of a JWK.
+func rsaPublicKeyFromJWK(n, e string) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, fmt.Errorf("jwtauth: decoding JWK modulus: %v", err)
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, fmt.Errorf("jwtauth: decoding JWK exponent: %v", err)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
+
+// ecPublicKeyFromJWK reconstructs an ECDSA public key from the JWK
+// curve name (crv) and base64url-encoded x/y coordinates.
+func ecPublicKeyFromJWK(crv, x, y string) (*ecdsa.PublicKey, error) {
+	curve, err := ecCurveFromJWK(crv)
+	if err != nil {
+		return nil, err
+	}
+	xBytes, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, fmt.Errorf("jwtauth: decoding JWK x coordinate: %v", err)
+	}
+	yBytes, err := base64.RawURLEncoding.DecodeString(y)
+	if err != nil {
+		return nil, fmt.Errorf("jwtauth: decoding JWK y coordinate: %v", err)
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(xBytes),
+		Y:     new(big.Int).SetBytes(yBytes),
+	}, nil
+}
+
+// ecCurveFromJWK maps a JWK "crv" value to its elliptic.Curve. Only
+// P-256 is supported, matching the ES256 algorithm this package verifies.
+func ecCurveFromJWK(crv string) (elliptic.Curve, error) {
+	if crv != "P-256" {
+		return nil, fmt.Errorf("jwtauth: unsupported JWK curve %q", crv)
+	}
+	return elliptic.P256(), nil
+}
+
+func (j *jwksKey) Key(alg, kid string) (interface{}, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	key, ok := j.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("jwtauth: no JWKS key for kid %q", kid)
+	}
+	return key, nil
+}
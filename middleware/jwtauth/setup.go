@@ -0,0 +1,128 @@
+package jwtauth
+
+import (
+	"time"
+
+	"github.com/nwcode4hire/caddy/middleware"
+)
+
+// NewGenerator returns a middleware.Generator for a directive whose
+// block configures JWT verification:
+//
+//	jwtauth {
+//		algorithm RS256
+//		key_file  /etc/caddy/jwt.pub
+//		issuer    https://issuer.example.com
+//		audience  my-api
+//		require   sub
+//		protect   /api
+//	}
+//
+// Key material may instead be supplied inline with `key <pem-or-secret>`,
+// or fetched from a JWKS endpoint with `jwks <url> [refresh-interval]`.
+func NewGenerator() middleware.Generator {
+	return func(c middleware.Controller) (middleware.Middleware, error) {
+		cfg := Config{Algorithm: "HS256"}
+
+		// Key material is parsed against cfg.Algorithm, but algorithm
+		// may appear anywhere in the block relative to key/key_file/
+		// jwks. Collect the raw source during this pass and construct
+		// the KeySource in a second pass below, once cfg.Algorithm has
+		// its final value, so directive order can't silently change
+		// which algorithm the key is parsed as.
+		var source struct {
+			kind         string // "key", "key_file", or "jwks"
+			raw          []byte
+			path         string
+			jwksURL      string
+			jwksInterval time.Duration
+		}
+
+		for c.Next() {
+			for c.NextBlock() {
+				switch c.Val() {
+				case "algorithm":
+					if !c.NextArg() {
+						return nil, c.ArgErr()
+					}
+					cfg.Algorithm = c.Val()
+
+				case "key":
+					if !c.NextArg() {
+						return nil, c.ArgErr()
+					}
+					source.kind = "key"
+					source.raw = []byte(c.Val())
+
+				case "key_file":
+					if !c.NextArg() {
+						return nil, c.ArgErr()
+					}
+					source.kind = "key_file"
+					source.path = c.Val()
+
+				case "jwks":
+					if !c.NextArg() {
+						return nil, c.ArgErr()
+					}
+					source.kind = "jwks"
+					source.jwksURL = c.Val()
+					source.jwksInterval = defaultJWKSRefresh
+					if c.NextArg() {
+						d, err := time.ParseDuration(c.Val())
+						if err != nil {
+							return nil, c.Err("invalid JWKS refresh interval: " + err.Error())
+						}
+						source.jwksInterval = d
+					}
+
+				case "issuer":
+					if !c.NextArg() {
+						return nil, c.ArgErr()
+					}
+					cfg.Issuer = c.Val()
+
+				case "audience":
+					if !c.NextArg() {
+						return nil, c.ArgErr()
+					}
+					cfg.Audience = c.Val()
+
+				case "require":
+					args := c.RemainingArgs()
+					if len(args) == 0 {
+						return nil, c.ArgErr()
+					}
+					cfg.RequiredClaims = append(cfg.RequiredClaims, args...)
+
+				case "protect":
+					args := c.RemainingArgs()
+					if len(args) == 0 {
+						return nil, c.ArgErr()
+					}
+					cfg.Protect = append(cfg.Protect, args...)
+
+				default:
+					return nil, c.ArgErr()
+				}
+			}
+		}
+
+		var err error
+		switch source.kind {
+		case "key":
+			cfg.Keys, err = newInlineKey(cfg.Algorithm, source.raw)
+		case "key_file":
+			cfg.Keys, err = newFileKey(cfg.Algorithm, source.path)
+		case "jwks":
+			cfg.Keys, err = newJWKSKey(c, source.jwksURL, source.jwksInterval)
+		default:
+			return nil, c.Err("jwtauth: no key material configured (use key, key_file, or jwks)")
+		}
+		if err != nil {
+			return nil, c.Err(err.Error())
+		}
+
+		return Middleware(cfg), nil
+	}
+}
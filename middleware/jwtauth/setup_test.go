@@ -0,0 +1,209 @@
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+
+	"github.com/nwcode4hire/caddy/middleware"
+)
+
+// writeECPublicKeyPEM generates an EC key pair and writes its public
+// key's PEM to a file under t.TempDir for use with a key_file
+// directive, returning the file path and the private key to sign
+// test tokens with.
+func writeECPublicKeyPEM(t *testing.T) (path string, key *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating EC key: %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshaling EC public key: %v", err)
+	}
+	block := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	path = filepath.Join(t.TempDir(), "jwt.pub")
+	if err := os.WriteFile(path, block, 0o600); err != nil {
+		t.Fatalf("writing EC public key: %v", err)
+	}
+	return path, key
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("signing ES256 token: %v", err)
+	}
+	return signed
+}
+
+// fakeController is a minimal Controller backed by a whitespace
+// tokenizer, just enough to drive NewGenerator in tests without
+// depending on the server's own Caddyfile parser.
+type fakeController struct {
+	tokens []string
+	cursor int
+	lines  []int
+}
+
+func newFakeController(input string) *fakeController {
+	c := &fakeController{cursor: -1}
+	for i, line := range strings.Split(input, "\n") {
+		spaced := strings.NewReplacer("{", " { ", "}", " } ").Replace(line)
+		for _, f := range strings.Fields(spaced) {
+			c.tokens = append(c.tokens, f)
+			c.lines = append(c.lines, i+1)
+		}
+	}
+	return c
+}
+
+func (c *fakeController) Next() bool {
+	if c.cursor+1 >= len(c.tokens) {
+		return false
+	}
+	c.cursor++
+	return true
+}
+
+func (c *fakeController) sameLine(i int) bool {
+	return c.cursor >= 0 && i < len(c.tokens) && c.lines[i] == c.lines[c.cursor]
+}
+
+func (c *fakeController) NextArg() bool {
+	if !c.sameLine(c.cursor + 1) {
+		return false
+	}
+	c.cursor++
+	return true
+}
+
+func (c *fakeController) NextLine() bool {
+	if c.cursor+1 >= len(c.tokens) || c.sameLine(c.cursor+1) {
+		return false
+	}
+	c.cursor++
+	return true
+}
+
+// NextBlock implements a single-level block, which is all these tests need.
+func (c *fakeController) NextBlock() bool {
+	if !c.NextArg() && !c.NextLine() {
+		return false
+	}
+	if c.Val() == "{" {
+		return c.NextBlock()
+	}
+	if c.Val() == "}" {
+		return false
+	}
+	return true
+}
+
+func (c *fakeController) NestingLevel() int { return 0 }
+
+func (c *fakeController) Val() string {
+	if c.cursor < 0 || c.cursor >= len(c.tokens) {
+		return ""
+	}
+	return c.tokens[c.cursor]
+}
+
+func (c *fakeController) Args(targets ...*string) bool {
+	for _, t := range targets {
+		if !c.NextArg() {
+			return false
+		}
+		*t = c.Val()
+	}
+	return true
+}
+
+func (c *fakeController) RemainingArgs() []string {
+	var args []string
+	for c.sameLine(c.cursor+1) && c.tokens[c.cursor+1] != "{" {
+		c.cursor++
+		args = append(args, c.Val())
+	}
+	return args
+}
+
+func (c *fakeController) ArgErr() error { return c.Err("expected an argument") }
+
+func (c *fakeController) Err(msg string) error {
+	line := 0
+	if c.cursor >= 0 && c.cursor < len(c.lines) {
+		line = c.lines[c.cursor]
+	}
+	return &fakeControllerErr{line: line, msg: msg}
+}
+
+type fakeControllerErr struct {
+	line int
+	msg  string
+}
+
+func (e *fakeControllerErr) Error() string { return e.msg }
+
+func (c *fakeController) Startup(func() error)  {}
+func (c *fakeController) Shutdown(func() error) {}
+func (c *fakeController) Root() string          { return "" }
+func (c *fakeController) Host() string          { return "" }
+func (c *fakeController) Port() string          { return "" }
+func (c *fakeController) Context() middleware.Path {
+	var p middleware.Path
+	return p
+}
+func (c *fakeController) OnEvent(name middleware.EventName, fn func(middleware.EventInfo) error) {
+}
+func (c *fakeController) OnEventPriority(name middleware.EventName, priority int, fn func(middleware.EventInfo) error) {
+}
+
+var _ middleware.Controller = (*fakeController)(nil)
+
+func TestNewGeneratorParsesKeyFileUnderFinalAlgorithmRegardlessOfOrder(t *testing.T) {
+	// Regression test: "algorithm ES256" appears AFTER "key_file" in
+	// the block. If the key were parsed against the HS256 default in
+	// effect when key_file was read (the bug), cfg.Keys would hand
+	// back the file's raw bytes as an HMAC secret instead of an EC
+	// public key, and verification of a validly-signed ES256 token
+	// would fail.
+	path, key := writeECPublicKeyPEM(t)
+
+	gen := NewGenerator()
+	c := newFakeController("jwtauth {\n  key_file " + path + "\n  algorithm ES256\n  protect /api\n}\n")
+	mw, err := gen(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	raw := signES256(t, key)
+	handler := mw(func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/widgets", nil)
+	req.Header.Set("Authorization", "Bearer "+raw)
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d (key_file should be parsed as ES256, not the HS256 default in effect when it was read)", rec.Code, http.StatusOK)
+	}
+}
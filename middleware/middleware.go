@@ -38,10 +38,20 @@ type (
 		// to load the next token as long as it opens a block or
 		// is already in a block. It returns true if a token was
 		// loaded, or false when the block's closing curly brace
-		// was loaded and thus the block ended. Nested blocks are
-		// not (currently) supported.
+		// was loaded and thus the block ended. Blocks may be nested:
+		// every additional '{' encountered while already inside a
+		// block increases the nesting level, and NextBlock only
+		// returns false once the matching '}' for the outermost
+		// block has been consumed. Use NestingLevel to inspect the
+		// current depth.
 		NextBlock() bool
 
+		// NestingLevel returns the current nesting depth as tracked
+		// by NextBlock. It is 0 outside of any block, 1 inside the
+		// outermost block, 2 inside a block nested within that one,
+		// and so on.
+		NestingLevel() int
+
 		// Val gets the text of the current token.
 		Val() string
 
@@ -86,5 +96,17 @@ type (
 		// Context returns the path scope that the Controller is in.
 		// Note: This is not currently used, but may be in the future.
 		Context() Path
+
+		// OnEvent subscribes fn to be called whenever the server emits
+		// name (see OnEvent and the EventName constants). It is a thin
+		// pass-through to the package-level OnEvent so that Generators
+		// can wire up event handlers without a separate import.
+		OnEvent(name EventName, fn func(EventInfo) error)
+
+		// OnEventPriority is like OnEvent but lets a Generator control
+		// where fn runs relative to other subscribers of the same
+		// event. It is a thin pass-through to the package-level
+		// OnEventPriority.
+		OnEventPriority(name EventName, priority int, fn func(EventInfo) error)
 	}
 )
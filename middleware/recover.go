@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"runtime"
+	"sync"
+)
+
+// maxStackSize bounds how much of the recovering goroutine's stack is
+// captured for a panic, so a deeply recursive panic can't blow up memory
+// or logs.
+const maxStackSize = 16 << 10 // 16 KB
+
+// PanicHandler is called with the panic value and the captured stack
+// trace after Recover has intercepted a panic. If it returns true, it
+// is considered to have written the response itself and no further
+// handlers in the chain are tried. Handlers are tried in registration
+// order via RegisterPanicHandler.
+type PanicHandler func(w http.ResponseWriter, r *http.Request, recovered interface{}, stack []byte) bool
+
+var (
+	panicHandlersMu sync.Mutex
+	panicHandlers   []PanicHandler
+)
+
+// RegisterPanicHandler appends h to the chain of handlers Recover
+// dispatches to. Handlers run in the order they were registered,
+// before the built-in DefaultPanicHandler, which runs last and only
+// when every registered handler declines the panic, so that it is
+// still reported. RegisterPanicHandler may be called concurrently with
+// Recover serving requests in other goroutines.
+func RegisterPanicHandler(h PanicHandler) {
+	panicHandlersMu.Lock()
+	defer panicHandlersMu.Unlock()
+	panicHandlers = append(panicHandlers, h)
+}
+
+// DefaultPanicHandler logs the panic and its stack trace to the
+// server's error log and emits a RequestErrorEvent carrying the same
+// information, then writes a generic 500 response. It never returns
+// false, so it is effectively the handler of last resort.
+func DefaultPanicHandler(w http.ResponseWriter, r *http.Request, recovered interface{}, stack []byte) bool {
+	log.Printf("[ERROR] %s: panic: %v\n%s", r.URL.Path, recovered, stack)
+	EmitEvent(RequestErrorEvent, map[string]interface{}{
+		"request":   r,
+		"recovered": recovered,
+		"stack":     stack,
+	})
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte("Internal Server Error"))
+	return true
+}
+
+// Recover is a Middleware that recovers from panics in the handlers
+// further down the chain, converting them into a 500 response instead
+// of taking down the whole server. NewRecoverGenerator returns it for
+// the `recover` directive; nothing in this package adds it to a host's
+// chain without that directive.
+func Recover(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				stack := make([]byte, maxStackSize)
+				stack = stack[:runtime.Stack(stack, false)]
+
+				if isHijacked(w) {
+					log.Printf("[ERROR] %s: panic on hijacked connection: %v\n%s", r.URL.Path, rec, stack)
+					return
+				}
+
+				panicHandlersMu.Lock()
+				handlers := append([]PanicHandler(nil), panicHandlers...)
+				panicHandlersMu.Unlock()
+
+				handled := false
+				for _, h := range handlers {
+					if h(w, r, rec, stack) {
+						handled = true
+						break
+					}
+				}
+				if !handled {
+					DefaultPanicHandler(w, r, rec, stack)
+				}
+			}
+		}()
+		next(w, r)
+	}
+}
+
+// isHijacked reports whether w's underlying connection has already
+// been taken over by the handler (e.g. for a websocket upgrade), in
+// which case nothing further should be written to it. It can only tell
+// for a writer that has a Hijacked() bool method; for any other writer,
+// including net/http's own, it reports false.
+func isHijacked(w http.ResponseWriter) bool {
+	hijacked, ok := w.(interface{ Hijacked() bool })
+	if !ok {
+		return false
+	}
+	return hijacked.Hijacked()
+}
+
+// NewRecoverGenerator returns a Generator for the `recover` directive:
+// `recover` installs Recover, and `recover off` installs a pass-through
+// Middleware that adds no panic recovery. Any other configuration is a
+// parse error.
+func NewRecoverGenerator() Generator {
+	return func(c Controller) (Middleware, error) {
+		enabled := true
+		for c.Next() {
+			args := c.RemainingArgs()
+			switch len(args) {
+			case 0:
+				// recover (with no args) installs Recover
+			case 1:
+				if args[0] != "off" {
+					return nil, c.ArgErr()
+				}
+				enabled = false
+			default:
+				return nil, c.ArgErr()
+			}
+		}
+
+		if !enabled {
+			return func(next http.HandlerFunc) http.HandlerFunc { return next }, nil
+		}
+		return Recover, nil
+	}
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// withPanicHandlers swaps panicHandlers for the duration of fn, then
+// restores it, so tests don't leak registrations into one another.
+func withPanicHandlers(t *testing.T, fn func()) {
+	t.Helper()
+	orig := panicHandlers
+	panicHandlers = nil
+	defer func() { panicHandlers = orig }()
+	fn()
+}
+
+func TestRecoverRunsRegisteredHandlerBeforeDefault(t *testing.T) {
+	withPanicHandlers(t, func() {
+		var called bool
+		RegisterPanicHandler(func(w http.ResponseWriter, r *http.Request, recovered interface{}, stack []byte) bool {
+			called = true
+			w.WriteHeader(599)
+			return true
+		})
+
+		handler := Recover(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if !called {
+			t.Fatal("expected the registered handler to run")
+		}
+		if rec.Code != 599 {
+			t.Fatalf("got status %d, want 599 (written by the registered handler)", rec.Code)
+		}
+	})
+}
+
+func TestRecoverFallsBackToDefaultWhenNoHandlerClaimsIt(t *testing.T) {
+	withPanicHandlers(t, func() {
+		var called bool
+		RegisterPanicHandler(func(w http.ResponseWriter, r *http.Request, recovered interface{}, stack []byte) bool {
+			called = true
+			return false // declines; Recover must fall back to DefaultPanicHandler
+		})
+
+		handler := Recover(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if !called {
+			t.Fatal("expected the registered handler to run")
+		}
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("got status %d, want %d from DefaultPanicHandler", rec.Code, http.StatusInternalServerError)
+		}
+	})
+}
+
+func TestRecoverUsesDefaultHandlerWhenNoneRegistered(t *testing.T) {
+	withPanicHandlers(t, func() {
+		handler := Recover(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("got status %d, want %d", rec.Code, http.StatusInternalServerError)
+		}
+	})
+}
+
+// TestRegisterPanicHandlerConcurrentWithRecover exercises registering
+// a handler while Recover is serving panicking requests in other
+// goroutines, matching real deployments where a plugin might register
+// a handler after the server has already started serving. Run with
+// -race to verify panicHandlers is properly guarded.
+func TestRegisterPanicHandlerConcurrentWithRecover(t *testing.T) {
+	withPanicHandlers(t, func() {
+		handler := Recover(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				RegisterPanicHandler(func(w http.ResponseWriter, r *http.Request, recovered interface{}, stack []byte) bool {
+					return false
+				})
+			}()
+			go func() {
+				defer wg.Done()
+				rec := httptest.NewRecorder()
+				handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestRecoverDoesNotRecoverWithoutAPanic(t *testing.T) {
+	handler := Recover(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusTeapot)
+	}
+}